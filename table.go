@@ -2,8 +2,10 @@ package tview
 
 import (
 	"log"
+	"sort"
 
 	"github.com/gdamore/tcell"
+	"github.com/mattn/go-runewidth"
 )
 
 // TableCell represents one cell inside a Table.
@@ -23,8 +25,226 @@ type TableCell struct {
 	// The color of the cell text.
 	Color tcell.Color
 
+	// The background color of the cell. If this is tcell.ColorDefault, the
+	// table's background color is used instead.
+	BackgroundColor tcell.Color
+
+	// The style attributes of the cell (e.g. tcell.AttrBold). Combine with
+	// the bitwise OR operator.
+	Attributes tcell.AttrMask
+
+	// The weight by which this cell's column is expanded when the available
+	// screen space exceeds the sum of the column widths. A column's
+	// expansion value is the highest one among its visible cells; columns
+	// with a higher expansion value get a proportionally larger share of the
+	// leftover space. A value of 0 (the default) means the column does not
+	// expand.
+	Expansion int
+
 	// Whether or not this cell may be selected.
 	Selectable bool
+
+	// An optional reference object, held so the application can attach
+	// domain data (e.g. a struct pointer) to a cell and retrieve it later,
+	// e.g. from a selection handler, without maintaining a parallel index.
+	Reference interface{}
+
+	// The position and width of the cell the last time it was drawn, in
+	// screen coordinates. Used by GetLastPosition().
+	x, y, width int
+}
+
+// NewTableCell returns a new table cell with sensible defaults. That is, a
+// transparent background (matching the table's background color) and the
+// primary text color.
+func NewTableCell(text string) *TableCell {
+	return &TableCell{
+		Text:            text,
+		Align:           AlignLeft,
+		Color:           Styles.PrimaryTextColor,
+		BackgroundColor: tcell.ColorDefault,
+	}
+}
+
+// SetReference allows you to store a reference of any type in this cell.
+// This will allow you to establish a mapping from the cell to your
+// actual data.
+func (c *TableCell) SetReference(reference interface{}) *TableCell {
+	c.Reference = reference
+	return c
+}
+
+// GetReference returns this cell's reference object.
+func (c *TableCell) GetReference() interface{} {
+	return c.Reference
+}
+
+// GetLastPosition returns the position of the cell the last time it was
+// drawn on screen. If the cell has not been drawn yet, the return values are
+// undefined.
+func (c *TableCell) GetLastPosition() (x, y, width int) {
+	return c.x, c.y, c.width
+}
+
+// TableContent is the data source of a Table. When drawing, the Table only
+// asks for cells in the visible rows (plus fixed rows), so a TableContent
+// implementation may lazily materialize its cells, e.g. to back a Table with
+// a log viewer, a database result set, or some other streaming feed without
+// paying the memory cost of rows the user never scrolls to. Columns are not
+// limited the same way: for those rows, every column from the first one up to
+// the right edge of the visible area is queried, including columns scrolled
+// out of view to the left.
+//
+// TableContentDefault is the implementation used by a Table unless
+// SetContent() is called with something else. It matches the behavior tables
+// had before TableContent existed, keeping all cells in memory.
+type TableContent interface {
+	// GetCell returns the cell at the given position, or nil if there is no
+	// content there.
+	GetCell(row, column int) *TableCell
+
+	// GetRowCount returns the total number of rows.
+	GetRowCount() int
+
+	// GetColumnCount returns the total number of columns.
+	GetColumnCount() int
+
+	// SetCell sets the cell at the given position.
+	SetCell(row, column int, cell *TableCell)
+
+	// RemoveRow removes the row at the given index.
+	RemoveRow(row int)
+
+	// RemoveColumn removes the column at the given index.
+	RemoveColumn(column int)
+
+	// InsertRow inserts a new empty row before the row at the given index.
+	InsertRow(row int)
+
+	// InsertColumn inserts a new empty column before the column at the given
+	// index.
+	InsertColumn(column int)
+
+	// Clear removes all content.
+	Clear()
+}
+
+// TableContentDefault is the default, in-memory TableContent implementation
+// used by a Table unless a different one is plugged in via SetContent(). It
+// keeps all cells in a two-dimensional slice, same as tables did before
+// TableContent was introduced.
+type TableContentDefault struct {
+	// The cells of the table. Rows first, then columns.
+	cells [][]*TableCell
+
+	// The rightmost column in the data set.
+	lastColumn int
+}
+
+// NewTableContentDefault returns a new default table content with no cells.
+func NewTableContentDefault() *TableContentDefault {
+	return &TableContentDefault{lastColumn: -1}
+}
+
+// SetCell sets the content of a cell at the specified position. Setting
+// cells in previously unknown rows and columns automatically extends the
+// internal representation, e.g. starting with a row of 100,000 immediately
+// creates 100,000 empty rows.
+//
+// To avoid unnecessary garbage collection, fill columns from left to right.
+func (c *TableContentDefault) SetCell(row, column int, cell *TableCell) {
+	if row >= len(c.cells) {
+		c.cells = append(c.cells, make([][]*TableCell, row-len(c.cells)+1)...)
+	}
+	rowLen := len(c.cells[row])
+	if column >= rowLen {
+		c.cells[row] = append(c.cells[row], make([]*TableCell, column-rowLen+1)...)
+		for col := rowLen; col < column; col++ {
+			c.cells[row][col] = &TableCell{}
+		}
+	}
+	c.cells[row][column] = cell
+	if column > c.lastColumn {
+		c.lastColumn = column
+	}
+}
+
+// GetCell returns the cell at the given position, or nil if it doesn't exist.
+func (c *TableContentDefault) GetCell(row, column int) *TableCell {
+	if row < 0 || row >= len(c.cells) || column < 0 || column >= len(c.cells[row]) {
+		return nil
+	}
+	return c.cells[row][column]
+}
+
+// GetRowCount returns the number of rows.
+func (c *TableContentDefault) GetRowCount() int {
+	return len(c.cells)
+}
+
+// GetColumnCount returns the number of columns.
+func (c *TableContentDefault) GetColumnCount() int {
+	return c.lastColumn + 1
+}
+
+// InsertRow inserts a new empty row before the row at the given index,
+// shifting that row and all following rows down by one. If row is beyond the
+// last row, nothing happens.
+func (c *TableContentDefault) InsertRow(row int) {
+	if row < 0 {
+		row = 0
+	}
+	if row >= len(c.cells) {
+		return
+	}
+	c.cells = append(c.cells, nil)
+	copy(c.cells[row+1:], c.cells[row:])
+	c.cells[row] = nil
+}
+
+// RemoveRow removes the row at the given index. Rows below it move up by one.
+func (c *TableContentDefault) RemoveRow(row int) {
+	if row < 0 || row >= len(c.cells) {
+		return
+	}
+	c.cells = append(c.cells[:row], c.cells[row+1:]...)
+}
+
+// InsertColumn inserts a new empty column before the column at the given
+// index for every row that already reaches that far, shifting that column
+// and all following ones to the right by one.
+func (c *TableContentDefault) InsertColumn(column int) {
+	for row := range c.cells {
+		if column >= len(c.cells[row]) {
+			continue
+		}
+		c.cells[row] = append(c.cells[row], nil)
+		copy(c.cells[row][column+1:], c.cells[row][column:])
+		c.cells[row][column] = &TableCell{}
+	}
+	if column <= c.lastColumn {
+		c.lastColumn++
+	}
+}
+
+// RemoveColumn removes the column at the given index. Columns to its right
+// move left by one.
+func (c *TableContentDefault) RemoveColumn(column int) {
+	for row := range c.cells {
+		if column >= len(c.cells[row]) {
+			continue
+		}
+		c.cells[row] = append(c.cells[row][:column], c.cells[row][column+1:]...)
+	}
+	if column <= c.lastColumn {
+		c.lastColumn--
+	}
+}
+
+// Clear removes all content.
+func (c *TableContentDefault) Clear() {
+	c.cells = nil
+	c.lastColumn = -1
 }
 
 // Table visualizes two-dimensional data consisting of rows and columns.
@@ -58,15 +278,28 @@ type Table struct {
 	// If there are no borders, the column separator.
 	separator rune
 
-	// The cells of the table. Rows first, then columns.
-	cells [][]*TableCell
-
-	// The rightmost column in the data set.
-	lastColumn int
+	// The table's data source. Defaults to a *TableContentDefault, which
+	// keeps all cells in memory. Set via SetContent() to plug in a
+	// virtualized/streaming source instead.
+	content TableContent
 
 	// The number of fixed rows / columns.
 	fixedRows, fixedColumns int
 
+	// Whether or not the table can be sorted by its users by pressing 's' on
+	// a selected column. sortByColumn() itself does not check this flag.
+	sortable bool
+
+	// Per-column comparison functions used when sorting. If a column has no
+	// entry, cells are compared lexically by their Text.
+	sortFuncs map[int]func(i, j int) bool
+
+	// The column currently sorted by, or -1 if the table has not been sorted.
+	sortedColumn int
+
+	// If true, the table is sorted in descending order by sortedColumn.
+	sortDescending bool
+
 	// Whether or not rows or columns can be selected. If both are set to true,
 	// cells can be selected.
 	rowsSelectable, columnsSelectable bool
@@ -78,6 +311,12 @@ type Table struct {
 	// right.
 	rowOffset, columnOffset int
 
+	// If set to true by Select(), the next Draw() disregards columnOffset
+	// when skipping columns and stops skipping as soon as the selected column
+	// is visible, even if it was set far away from columnOffset without any
+	// key input.
+	scrollToSelection bool
+
 	// If set to true, the table's last row will always be visible.
 	trackEnd bool
 
@@ -87,13 +326,41 @@ type Table struct {
 	// An optional function which gets called when the user presses Enter on a
 	// selected cell. If entire rows selected, the column value is undefined.
 	// Likewise for entire columns.
-	selected func(row, column int)
+	selected func(row, column int, cell *TableCell)
+
+	// An optional function which gets called whenever the selection changes,
+	// e.g. because the user moved the cursor, not just when Enter is pressed.
+	selectionChanged func(row, column int, cell *TableCell)
 
 	// An optional function which gets called when the user presses Escape, Tab,
 	// or Backtab. Also when the user presses Enter if nothing is selectable.
 	done func(key tcell.Key)
 }
 
+// setAttributes applies the bits set in attrs (e.g. tcell.AttrBold) to style,
+// since tcell.Style only exposes individual attribute setters.
+func setAttributes(style tcell.Style, attrs tcell.AttrMask) tcell.Style {
+	if attrs&tcell.AttrBold != 0 {
+		style = style.Bold(true)
+	}
+	if attrs&tcell.AttrBlink != 0 {
+		style = style.Blink(true)
+	}
+	if attrs&tcell.AttrDim != 0 {
+		style = style.Dim(true)
+	}
+	if attrs&tcell.AttrItalic != 0 {
+		style = style.Italic(true)
+	}
+	if attrs&tcell.AttrReverse != 0 {
+		style = style.Reverse(true)
+	}
+	if attrs&tcell.AttrUnderline != 0 {
+		style = style.Underline(true)
+	}
+	return style
+}
+
 // NewTable returns a new table.
 func NewTable() *Table {
 	return &Table{
@@ -101,14 +368,34 @@ func NewTable() *Table {
 		bordersColor: tcell.ColorWhite,
 		separator:    ' ',
 		trackEnd:     true,
-		lastColumn:   -1,
+		sortedColumn: -1,
+		content:      NewTableContentDefault(),
+	}
+}
+
+// SetContent sets the data source of the table, replacing whatever content
+// the table had before (including the default, in-memory one). This is the
+// way to back a Table with a virtualized provider, e.g. one that lazily
+// materializes cells for a log viewer or a database result set.
+func (t *Table) SetContent(content TableContent) *Table {
+	t.content = content
+	return t
+}
+
+// ensureContent makes sure t.content is not nil, defaulting it to a fresh
+// TableContentDefault. This keeps the zero value of Table (i.e. a *Table
+// obtained without calling NewTable()) usable, the way it was before content
+// was pulled out into its own interface.
+func (t *Table) ensureContent() {
+	if t.content == nil {
+		t.content = NewTableContentDefault()
 	}
 }
 
 // Clear removes all table data.
 func (t *Table) Clear() *Table {
-	t.cells = nil
-	t.lastColumn = -1
+	t.ensureContent()
+	t.content.Clear()
 	return t
 }
 
@@ -157,14 +444,28 @@ func (t *Table) SetSelectable(rows, columns bool) *Table {
 	return t
 }
 
-// SetSelected sets the selected cell. Depending on the selection settings
+// Select sets the selected cell. Depending on the selection settings
 // specified via SetSelectable(), this may be an entire row or column, or even
-// ignored completely.
-func (t *Table) SetSelected(row, column int) *Table {
+// ignored completely. The table scrolls so the selection is visible on the
+// next draw, even if it was set far away from the current scroll position.
+func (t *Table) Select(row, column int) *Table {
 	t.selectedRow, t.selectedColumn = row, column
+	t.scrollToSelection = true
 	return t
 }
 
+// SetSelected sets the selected cell. It is a deprecated alias for Select().
+//
+// Deprecated: Use Select() instead.
+func (t *Table) SetSelected(row, column int) *Table {
+	return t.Select(row, column)
+}
+
+// GetSelection returns the position of the current selection.
+func (t *Table) GetSelection() (row, column int) {
+	return t.selectedRow, t.selectedColumn
+}
+
 // SetOffset sets how many rows and columns should be skipped when drawing the
 // table. This is useful for large tables that do not fit on the screen.
 // Navigating a selection can change these values.
@@ -175,15 +476,49 @@ func (t *Table) SetOffset(row, column int) *Table {
 	return t
 }
 
+// GetOffset returns the current row and column offset, i.e. the number of
+// rows and columns skipped when drawing the table.
+func (t *Table) GetOffset() (row, column int) {
+	return t.rowOffset, t.columnOffset
+}
+
+// ScrollToBeginning scrolls the table to the top left corner.
+func (t *Table) ScrollToBeginning() *Table {
+	t.trackEnd = false
+	t.rowOffset = 0
+	t.columnOffset = 0
+	return t
+}
+
+// ScrollToEnd scrolls the table to the bottom left corner. The table will
+// keep track of the end of the table as new rows are added.
+func (t *Table) ScrollToEnd() *Table {
+	t.trackEnd = true
+	t.columnOffset = 0
+	return t
+}
+
 // SetSelectedFunc sets a handler which is called whenever the user presses the
 // Enter key on a selected cell/row/column. The handler receives the position of
-// the selection and its cell contents. If entire rows are selected, the column
-// index is undefined. Likewise for entire columns.
-func (t *Table) SetSelectedFunc(handler func(row, column int)) *Table {
+// the selection and the selected cell (which may be used to retrieve, say, its
+// Reference value). If entire rows are selected, the column index is
+// undefined and the cell is the one at that row and column index. Likewise
+// for entire columns.
+func (t *Table) SetSelectedFunc(handler func(row, column int, cell *TableCell)) *Table {
 	t.selected = handler
 	return t
 }
 
+// SetSelectionChangedFunc sets a handler which is called whenever the
+// selection changes, i.e. whenever the user moves the current selection, not
+// just when they press Enter. The handler receives the new position of the
+// selection and its cell. This is useful, for example, to keep another
+// widget in sync with the currently highlighted row.
+func (t *Table) SetSelectionChangedFunc(handler func(row, column int, cell *TableCell)) *Table {
+	t.selectionChanged = handler
+	return t
+}
+
 // SetDoneFunc sets a handler which is called whenever the user presses the
 // Escape, Tab, or Backtab key. If nothing is selected, it is also called when
 // user presses the Enter key (because pressing Enter on a selection triggers
@@ -193,30 +528,19 @@ func (t *Table) SetDoneFunc(handler func(key tcell.Key)) *Table {
 	return t
 }
 
-// SetCell sets the content of a cell the specified position. It is ok to
+// SetCell sets the content of a cell at the specified position. It is ok to
 // directly instantiate a TableCell object. If the cell has contain, at least
 // the Text and Color fields should be set.
 //
-// Note that setting cells in previously unknown rows and columns will
+// This is forwarded to the table's content (see SetContent()). With the
+// default content, setting cells in previously unknown rows and columns will
 // automatically extend the internal table representation, e.g. starting with
-// a row of 100,000 will immediately create 100,000 empty rows.
-//
-// To avoid unnecessary garbage collection, fill columns from left to right.
+// a row of 100,000 will immediately create 100,000 empty rows. To avoid
+// unnecessary garbage collection in that case, fill columns from left to
+// right.
 func (t *Table) SetCell(row, column int, cell *TableCell) *Table {
-	if row >= len(t.cells) {
-		t.cells = append(t.cells, make([][]*TableCell, row-len(t.cells)+1)...)
-	}
-	rowLen := len(t.cells[row])
-	if column >= rowLen {
-		t.cells[row] = append(t.cells[row], make([]*TableCell, column-rowLen+1)...)
-		for c := rowLen; c < column; c++ {
-			t.cells[row][c] = &TableCell{}
-		}
-	}
-	t.cells[row][column] = cell
-	if column > t.lastColumn {
-		t.lastColumn = column
-	}
+	t.ensureContent()
+	t.content.SetCell(row, column, cell)
 	return t
 }
 
@@ -224,14 +548,178 @@ func (t *Table) SetCell(row, column int, cell *TableCell) *Table {
 // TableCell object is always returns but it will be uninitialized if the cell
 // was not previously set.
 func (t *Table) GetCell(row, column int) *TableCell {
-	if row >= len(t.cells) || column >= len(t.cells[row]) {
+	t.ensureContent()
+	cell := t.content.GetCell(row, column)
+	if cell == nil {
 		return &TableCell{}
 	}
-	return t.cells[row][column]
+	return cell
+}
+
+// GetRowCount returns the number of rows in the table.
+func (t *Table) GetRowCount() int {
+	t.ensureContent()
+	return t.content.GetRowCount()
+}
+
+// GetColumnCount returns the number of columns in the table.
+func (t *Table) GetColumnCount() int {
+	t.ensureContent()
+	return t.content.GetColumnCount()
+}
+
+// InsertRow inserts a new empty row before the row at the given index,
+// shifting that row and all following rows down by one. If row is beyond the
+// last row, nothing happens (use SetCell to extend the table instead).
+func (t *Table) InsertRow(row int) *Table {
+	t.ensureContent()
+	if row < 0 {
+		row = 0
+	}
+	if row >= t.content.GetRowCount() {
+		return t
+	}
+	t.content.InsertRow(row)
+	if row <= t.selectedRow {
+		t.selectedRow++
+	}
+	return t
+}
+
+// RemoveRow removes the row at the given index. Rows below it move up by one.
+func (t *Table) RemoveRow(row int) *Table {
+	t.ensureContent()
+	if row < 0 || row >= t.content.GetRowCount() {
+		return t
+	}
+	t.content.RemoveRow(row)
+	if row < t.selectedRow || t.selectedRow >= t.content.GetRowCount() {
+		t.selectedRow--
+		if t.selectedRow < 0 {
+			t.selectedRow = 0
+		}
+	}
+	return t
+}
+
+// InsertColumn inserts a new empty column before the column at the given
+// index for every row that already reaches that far, shifting that column
+// and all following ones to the right by one.
+func (t *Table) InsertColumn(column int) *Table {
+	t.ensureContent()
+	t.content.InsertColumn(column)
+	if column <= t.selectedColumn {
+		t.selectedColumn++
+	}
+	return t
+}
+
+// RemoveColumn removes the column at the given index. Columns to its right
+// move left by one.
+func (t *Table) RemoveColumn(column int) *Table {
+	t.ensureContent()
+	t.content.RemoveColumn(column)
+	if column < t.selectedColumn || t.selectedColumn >= t.content.GetColumnCount() {
+		t.selectedColumn--
+		if t.selectedColumn < 0 {
+			t.selectedColumn = 0
+		}
+	}
+	return t
+}
+
+// SetSortable sets whether or not the user can sort the table by pressing
+// 's' on a selected column (click-to-sort follows once mouse support is
+// wired up). The default is false. Sorting leaves fixed rows untouched and
+// carries each cell's Reference along with its row.
+//
+// Sorting re-materializes every column of every non-fixed row through
+// GetCell/SetCell, which is fine for the default, in-memory content but
+// would defeat the point of a virtualized TableContent plugged in via
+// SetContent(). For any content other than the default one, sortByColumn
+// silently does nothing: provide pre-sorted data, or a content type with its
+// own cheap reordering, instead of enabling this.
+func (t *Table) SetSortable(sortable bool) *Table {
+	t.sortable = sortable
+	return t
+}
+
+// SetSortFunc sets the comparison function used when sorting the given
+// column: it must return true if the (non-fixed) row at index i should sort
+// before the row at index j. If no function has been set for a column, rows
+// are compared lexically by that column's cell Text.
+func (t *Table) SetSortFunc(column int, less func(i, j int) bool) *Table {
+	if t.sortFuncs == nil {
+		t.sortFuncs = make(map[int]func(i, j int) bool)
+	}
+	t.sortFuncs[column] = less
+	return t
+}
+
+// sortByColumn sorts the table's non-fixed rows by the given column. Sorting
+// the same column again toggles between ascending and descending order. This
+// re-shuffles cells via GetCell/SetCell rather than assuming an in-memory
+// [][]*TableCell, which keeps it correct for a custom, virtualized content,
+// but also means it materializes every cell of every non-fixed row to do so.
+// That's only cheap for the default content, which keeps everything in
+// memory already; for any other content, it's a no-op (see SetSortable).
+func (t *Table) sortByColumn(column int) {
+	t.ensureContent()
+	if _, ok := t.content.(*TableContentDefault); !ok {
+		return
+	}
+
+	rowCount, columnCount := t.content.GetRowCount(), t.content.GetColumnCount()
+	if column < 0 || column >= columnCount || rowCount <= t.fixedRows {
+		return
+	}
+
+	if t.sortedColumn == column {
+		t.sortDescending = !t.sortDescending
+	} else {
+		t.sortedColumn = column
+		t.sortDescending = false
+	}
+
+	less, ok := t.sortFuncs[column]
+	if !ok {
+		less = func(i, j int) bool {
+			return t.GetCell(i, column).Text < t.GetCell(j, column).Text
+		}
+	}
+
+	// Determine the new row order without touching the content yet, so
+	// "less" always compares the original, pre-sort cells.
+	order := make([]int, rowCount-t.fixedRows)
+	for index := range order {
+		order[index] = index + t.fixedRows
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		if t.sortDescending {
+			return less(order[j], order[i])
+		}
+		return less(order[i], order[j])
+	})
+
+	// Snapshot the rows in their new order, then write them back. This
+	// carries each cell's Reference along with its row.
+	snapshot := make([][]*TableCell, len(order))
+	for index, row := range order {
+		snapshot[index] = make([]*TableCell, columnCount)
+		for column := 0; column < columnCount; column++ {
+			snapshot[index][column] = t.content.GetCell(row, column)
+		}
+	}
+	for index, row := range snapshot {
+		for column, cell := range row {
+			t.content.SetCell(t.fixedRows+index, column, cell)
+		}
+	}
 }
 
 // Draw draws this primitive onto the screen.
 func (t *Table) Draw(screen tcell.Screen) {
+	t.ensureContent()
 	t.Box.Draw(screen)
 
 	// What's our available screen space?
@@ -243,12 +731,12 @@ func (t *Table) Draw(screen tcell.Screen) {
 	}
 
 	// Return the cell at the specified position (nil if it doesn't exist).
-	getCell := func(row, column int) *TableCell {
-		if row >= len(t.cells) || column >= len(t.cells[row]) {
-			return nil
-		}
-		return t.cells[row][column]
-	}
+	// Only the visible rows plus fixed rows are queried. For those rows,
+	// columns are queried from the first one up to the right edge of the
+	// visible area, including columns scrolled out of view to the left.
+	getCell := t.content.GetCell
+	rowCount := t.content.GetRowCount()
+	lastColumn := t.content.GetColumnCount() - 1
 
 	// Clamp row offsets.
 	log.Print(t.rowOffset, t.selectedRow, height)
@@ -270,19 +758,19 @@ func (t *Table) Draw(screen tcell.Screen) {
 		}
 	}
 	if t.borders {
-		if 2*(len(t.cells)-t.rowOffset) < height {
+		if 2*(rowCount-t.rowOffset) < height {
 			t.trackEnd = true
 		}
 	} else {
-		if len(t.cells)-t.rowOffset < height {
+		if rowCount-t.rowOffset < height {
 			t.trackEnd = true
 		}
 	}
 	if t.trackEnd {
 		if t.borders {
-			t.rowOffset = len(t.cells) - height/2
+			t.rowOffset = rowCount - height/2
 		} else {
-			t.rowOffset = len(t.cells) - height
+			t.rowOffset = rowCount - height
 		}
 	}
 	if t.rowOffset < 0 {
@@ -303,8 +791,8 @@ func (t *Table) Draw(screen tcell.Screen) {
 
 	// Determine the indices and widths of the columns which fit on the screen.
 	var (
-		columns, rows, widths   []int
-		tableHeight, tableWidth int
+		columns, rows, widths, expansions []int
+		tableHeight, tableWidth           int
 	)
 	rowStep := 1
 	if t.borders {
@@ -319,19 +807,21 @@ func (t *Table) Draw(screen tcell.Screen) {
 		tableHeight += rowStep
 		return true
 	}
-	for row := 0; row < t.fixedRows && row < len(t.cells); row++ { // Do the fixed rows first.
+	for row := 0; row < t.fixedRows && row < rowCount; row++ { // Do the fixed rows first.
 		if !indexRow(row) {
 			break
 		}
 	}
-	for row := t.fixedRows + t.rowOffset; row < len(t.cells); row++ { // Then the remaining rows.
+	for row := t.fixedRows + t.rowOffset; row < rowCount; row++ { // Then the remaining rows.
 		if !indexRow(row) {
 			break
 		}
 	}
 	var skipped, lastTableWidth int
+	forceColumnScroll := t.scrollToSelection // Select() may have jumped far right; don't trust columnOffset.
+	t.scrollToSelection = false
 ColumnLoop:
-	for column := 0; column <= t.lastColumn; column++ {
+	for column := 0; column <= lastColumn; column++ {
 		// If we've moved beyond the right border, we stop or skip a column.
 		for tableWidth-1 >= width { // -1 because we include one extra column if the separator falls on the right end of the box.
 			// We've moved beyond the available space.
@@ -344,7 +834,7 @@ ColumnLoop:
 			if t.columnsSelectable && t.selectedColumn-skipped == t.fixedColumns {
 				break ColumnLoop // The selected column reached the leftmost point before disappearing.
 			}
-			if t.columnsSelectable && skipped >= t.columnOffset &&
+			if t.columnsSelectable && (forceColumnScroll || skipped >= t.columnOffset) &&
 				(t.selectedColumn < column && lastTableWidth < width-1 || t.selectedColumn < column-1) {
 				break ColumnLoop // We've skipped as many as requested and the selection is visible.
 			}
@@ -358,19 +848,24 @@ ColumnLoop:
 			tableWidth -= widths[t.fixedColumns] + 1
 			columns = append(columns[:t.fixedColumns], columns[t.fixedColumns+1:]...)
 			widths = append(widths[:t.fixedColumns], widths[t.fixedColumns+1:]...)
+			expansions = append(expansions[:t.fixedColumns], expansions[t.fixedColumns+1:]...)
 		}
 
 		// What's this column's width?
 		maxWidth := -1
+		expansion := 0
 		for _, row := range rows {
 			if cell := getCell(row, column); cell != nil {
-				cellWidth := len(cell.Text)
+				cellWidth := runewidth.StringWidth(cell.Text)
 				if cell.MaxWidth > 0 && cell.MaxWidth < cellWidth {
 					cellWidth = cell.MaxWidth
 				}
 				if cellWidth > maxWidth {
 					maxWidth = cellWidth
 				}
+				if cell.Expansion > expansion {
+					expansion = cell.Expansion
+				}
 			}
 		}
 		if maxWidth < 0 {
@@ -380,11 +875,46 @@ ColumnLoop:
 		// Store new column info at the end.
 		columns = append(columns, column)
 		widths = append(widths, maxWidth)
+		expansions = append(expansions, expansion)
 		lastTableWidth = tableWidth
 		tableWidth += maxWidth + 1
 	}
+
+	// The loop above only re-trims the left edge *before* adding the next
+	// column, so overflow caused by appending the very last column never got
+	// a chance to be trimmed. Without this, a selected last column could be
+	// laid out past the right edge and never actually drawn. Keep trimming
+	// from the left as long as that doesn't push the selected column out of
+	// view.
+	for t.columnsSelectable && tableWidth-1 >= width && len(columns) > t.fixedColumns &&
+		t.selectedColumn-skipped != t.fixedColumns {
+		skipped++
+		lastTableWidth -= widths[t.fixedColumns] + 1
+		tableWidth -= widths[t.fixedColumns] + 1
+		columns = append(columns[:t.fixedColumns], columns[t.fixedColumns+1:]...)
+		widths = append(widths[:t.fixedColumns], widths[t.fixedColumns+1:]...)
+		expansions = append(expansions[:t.fixedColumns], expansions[t.fixedColumns+1:]...)
+	}
 	t.columnOffset = skipped
 
+	// Distribute any leftover horizontal space across the columns,
+	// proportionally to their expansion weights, so that wide tables fill
+	// the container instead of leaving a blank gap on the right.
+	if leftOver := width - tableWidth; leftOver > 0 {
+		var totalExpansion int
+		for _, expansion := range expansions {
+			totalExpansion += expansion
+		}
+		if totalExpansion > 0 {
+			for columnIndex, expansion := range expansions {
+				if expansion == 0 {
+					continue
+				}
+				widths[columnIndex] += leftOver * expansion / totalExpansion
+			}
+		}
+	}
+
 	// Helper function which draws border runes.
 	borderStyle := tcell.StyleDefault.Background(t.backgroundColor).Foreground(t.bordersColor)
 	selectedBorderStyle := tcell.StyleDefault.Background(t.bordersColor).Foreground(t.backgroundColor)
@@ -436,19 +966,26 @@ ColumnLoop:
 				drawBorder(columnX, rowY, t.separator, rowSelected)
 			}
 
-			// Get the cell.
+			// Get the cell. A TableContent is allowed to return nil for cells
+			// it hasn't materialized (e.g. a sparse or virtualized source).
 			cell := getCell(row, column)
+			if cell == nil {
+				cell = &TableCell{}
+			}
 
-			// Determine colors.
-			bgColor := t.backgroundColor
+			// Determine colors and attributes.
+			bgColor := cell.BackgroundColor
+			if bgColor == tcell.ColorDefault {
+				bgColor = t.backgroundColor
+			}
 			textColor := cell.Color
+			attrs := cell.Attributes
 			if cellSelected {
-				bgColor = cell.Color
-				textColor = t.backgroundColor
+				bgColor, textColor = textColor, bgColor
 			}
 
 			// Draw cell background.
-			bgStyle := tcell.StyleDefault.Background(bgColor)
+			bgStyle := setAttributes(tcell.StyleDefault.Background(bgColor), attrs)
 			for pos := 0; pos < columnWidth && columnX+1+pos < width; pos++ {
 				screen.SetContent(x+columnX+1+pos, y+rowY, ' ', nil, bgStyle)
 			}
@@ -458,11 +995,15 @@ ColumnLoop:
 			if columnX+1+w >= width {
 				w = width - columnX - 1
 			}
-			text := []rune(cell.Text)
-			if w < len(text) && w > 0 {
-				text = append(text[:w-1], GraphicsEllipsis)
+			text := cell.Text
+			if w > 0 && runewidth.StringWidth(text) > w {
+				text = runewidth.Truncate(text, w, string(GraphicsEllipsis))
 			}
-			Print(screen, string(text), x+columnX+1, y+rowY, w, cell.Align, textColor)
+			Print(screen, text, x+columnX+1, y+rowY, w, cell.Align, textColor)
+
+			// Remember the cell's screen position so popups (context menus,
+			// tooltips) can be placed relative to it after the fact.
+			cell.x, cell.y, cell.width = x+columnX+1, y+rowY, w
 		}
 
 		// Draw bottom border.
@@ -503,6 +1044,7 @@ ColumnLoop:
 // InputHandler returns the handler for this primitive.
 func (t *Table) InputHandler() func(event *tcell.EventKey, setFocus func(p Primitive)) {
 	return func(event *tcell.EventKey, setFocus func(p Primitive)) {
+		t.ensureContent()
 		key := event.Key()
 
 		if (!t.rowsSelectable && !t.columnsSelectable && key == tcell.KeyEnter) ||
@@ -515,6 +1057,9 @@ func (t *Table) InputHandler() func(event *tcell.EventKey, setFocus func(p Primi
 			return
 		}
 
+		previousSelectedRow, previousSelectedColumn := t.selectedRow, t.selectedColumn
+		rowCount, lastColumn := t.content.GetRowCount(), t.content.GetColumnCount()-1
+
 		// Movement functions.
 		var (
 			home = func() {
@@ -530,8 +1075,8 @@ func (t *Table) InputHandler() func(event *tcell.EventKey, setFocus func(p Primi
 
 			end = func() {
 				if t.rowsSelectable {
-					t.selectedRow = len(t.cells) - 1
-					t.selectedColumn = t.lastColumn
+					t.selectedRow = rowCount - 1
+					t.selectedColumn = lastColumn
 				} else {
 					t.trackEnd = true
 					t.columnOffset = 0
@@ -541,8 +1086,8 @@ func (t *Table) InputHandler() func(event *tcell.EventKey, setFocus func(p Primi
 			down = func() {
 				if t.rowsSelectable {
 					t.selectedRow++
-					if t.selectedRow >= len(t.cells) {
-						t.selectedRow = len(t.cells) - 1
+					if t.selectedRow >= rowCount {
+						t.selectedRow = rowCount - 1
 					}
 				} else {
 					t.rowOffset++
@@ -575,8 +1120,8 @@ func (t *Table) InputHandler() func(event *tcell.EventKey, setFocus func(p Primi
 			right = func() {
 				if t.columnsSelectable {
 					t.selectedColumn++
-					if t.selectedColumn > t.lastColumn {
-						t.selectedColumn = t.lastColumn
+					if t.selectedColumn > lastColumn {
+						t.selectedColumn = lastColumn
 					}
 				} else {
 					t.columnOffset++
@@ -586,8 +1131,8 @@ func (t *Table) InputHandler() func(event *tcell.EventKey, setFocus func(p Primi
 			pageDown = func() {
 				if t.rowsSelectable {
 					t.selectedRow += t.visibleRows
-					if t.selectedRow >= len(t.cells) {
-						t.selectedRow = len(t.cells) - 1
+					if t.selectedRow >= rowCount {
+						t.selectedRow = rowCount - 1
 					}
 				} else {
 					t.rowOffset += t.visibleRows
@@ -622,6 +1167,10 @@ func (t *Table) InputHandler() func(event *tcell.EventKey, setFocus func(p Primi
 				left()
 			case 'l':
 				right()
+			case 's':
+				if t.sortable && t.columnsSelectable {
+					t.sortByColumn(t.selectedColumn)
+				}
 			}
 		case tcell.KeyHome:
 			home()
@@ -641,8 +1190,13 @@ func (t *Table) InputHandler() func(event *tcell.EventKey, setFocus func(p Primi
 			pageUp()
 		case tcell.KeyEnter:
 			if (t.rowsSelectable || t.columnsSelectable) && t.selected != nil {
-				t.selected(t.selectedRow, t.selectedColumn)
+				t.selected(t.selectedRow, t.selectedColumn, t.GetCell(t.selectedRow, t.selectedColumn))
 			}
 		}
+
+		if (t.rowsSelectable || t.columnsSelectable) && t.selectionChanged != nil &&
+			(t.selectedRow != previousSelectedRow || t.selectedColumn != previousSelectedColumn) {
+			t.selectionChanged(t.selectedRow, t.selectedColumn, t.GetCell(t.selectedRow, t.selectedColumn))
+		}
 	}
 }
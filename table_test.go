@@ -0,0 +1,110 @@
+package tview
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/gdamore/tcell"
+)
+
+// TestSortByColumnTogglesOrderAndCarriesReference verifies that sorting the
+// same column twice toggles between ascending and descending order, and that
+// each row's Reference travels with it.
+func TestSortByColumnTogglesOrderAndCarriesReference(t *testing.T) {
+	table := NewTable()
+	table.SetSortable(true)
+	values := []int{3, 1, 2}
+	for row, value := range values {
+		cell := NewTableCell(strconv.Itoa(value))
+		cell.SetReference(value)
+		table.SetCell(row, 0, cell)
+	}
+
+	table.sortByColumn(0)
+	want := []int{1, 2, 3}
+	for row, value := range want {
+		if got := table.GetCell(row, 0).GetReference(); got != value {
+			t.Fatalf("ascending sort: row %d: got reference %v, want %v", row, got, value)
+		}
+	}
+
+	table.sortByColumn(0)
+	want = []int{3, 2, 1}
+	for row, value := range want {
+		if got := table.GetCell(row, 0).GetReference(); got != value {
+			t.Fatalf("descending sort: row %d: got reference %v, want %v", row, got, value)
+		}
+	}
+}
+
+// TestSelectScrollsLastColumnIntoView verifies that selecting the table's
+// last column always brings it into view on the next draw, even when its
+// width lands exactly at the edge of the available space.
+func TestSelectScrollsLastColumnIntoView(t *testing.T) {
+	table := NewTable()
+	table.SetSelectable(true, true)
+	for column := 0; column < 30; column++ {
+		table.SetCell(0, column, NewTableCell("c"))
+	}
+	table.SetRect(0, 0, 40, 10)
+
+	screen := tcell.NewSimulationScreen("")
+	screen.Init()
+	screen.SetSize(40, 10)
+
+	table.Select(0, 29)
+	table.Draw(screen)
+
+	if _, _, width := table.GetCell(0, 29).GetLastPosition(); width == 0 {
+		t.Fatalf("last column not drawn after Select()")
+	}
+}
+
+// sparseTableContent is a TableContent that returns nil for cells it hasn't
+// explicitly been given, the way a virtualized or streaming provider would
+// for content it hasn't materialized yet.
+type sparseTableContent struct {
+	cells         map[[2]int]*TableCell
+	rows, columns int
+}
+
+func (c *sparseTableContent) GetCell(row, column int) *TableCell {
+	return c.cells[[2]int{row, column}]
+}
+
+func (c *sparseTableContent) GetRowCount() int    { return c.rows }
+func (c *sparseTableContent) GetColumnCount() int { return c.columns }
+
+func (c *sparseTableContent) SetCell(row, column int, cell *TableCell) {
+	if c.cells == nil {
+		c.cells = make(map[[2]int]*TableCell)
+	}
+	c.cells[[2]int{row, column}] = cell
+}
+
+func (c *sparseTableContent) RemoveRow(row int)       {}
+func (c *sparseTableContent) RemoveColumn(column int) {}
+func (c *sparseTableContent) InsertRow(row int)       {}
+func (c *sparseTableContent) InsertColumn(column int) {}
+func (c *sparseTableContent) Clear()                  { c.cells = nil }
+
+// TestDrawTreatsNilCellsAsEmpty verifies that Draw does not panic when a
+// TableContent returns nil for a visible cell, as TableContent.GetCell is
+// documented to be allowed to do.
+func TestDrawTreatsNilCellsAsEmpty(t *testing.T) {
+	content := &sparseTableContent{rows: 2, columns: 2}
+	content.SetCell(0, 0, NewTableCell("a"))
+	content.SetCell(0, 1, NewTableCell("b"))
+	content.SetCell(1, 0, NewTableCell("c"))
+	// Row 1, column 1 is left nil on purpose.
+
+	table := NewTable()
+	table.SetContent(content)
+	table.SetRect(0, 0, 10, 10)
+
+	screen := tcell.NewSimulationScreen("")
+	screen.Init()
+	screen.SetSize(10, 10)
+
+	table.Draw(screen)
+}